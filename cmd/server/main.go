@@ -6,34 +6,52 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"sb-module/internal/config"
 	"sb-module/internal/database"
+	"sb-module/internal/database/migrations"
+	"sb-module/internal/events"
 	"sb-module/internal/handlers"
 	"sb-module/internal/middleware"
+	"sb-module/internal/queue"
 	"sb-module/pkg/logger"
 
 	"github.com/gorilla/mux"
 )
 
+// main dispatches to the migrate subcommand when "migrate" appears among
+// the arguments, treating everything before it as flags for config.Load
+// and everything after it as the subcommand's own arguments; otherwise it
+// starts the server, matching the binary's original (pre-subcommand)
+// behavior.
 func main() {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg != "migrate" {
+			continue
+		}
+		os.Args = append([]string{os.Args[0]}, args[:i]...)
+		if err := runMigrate(args[i+1:]); err != nil {
+			fmt.Printf("migrate: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	runServe()
+}
+
+func runServe() {
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Printf("Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	logLevel := "info"
-	if cfg.DebugMode {
-		logLevel = "debug"
-	}
-	if cfg.IsProduction() {
-		logLevel = "warn"
-	}
-
-	log := logger.New(logLevel)
+	log := logger.New(cfg.LogLevel)
 	log.Info("Starting application", "environment", cfg.Environment, "debug", cfg.DebugMode)
 
 	db, err := database.Connect(cfg.DatabaseURL, cfg.MaxConnections)
@@ -52,11 +70,53 @@ func main() {
 		log.Fatal("Database ping failed", "error", err)
 	}
 
+	if cfg.Database.AutoMigrate {
+		log.Info("Running pending migrations")
+		if err := migrations.Run(db, migrations.Up, 0); err != nil {
+			log.Fatal("Failed to run migrations", "error", err)
+		}
+	}
+
+	eventPublisher, err := events.NewPublisher(cfg)
+	if err != nil {
+		log.Fatal("Failed to build event publisher", "error", err)
+	}
+
+	relayCtx, cancelRelay := context.WithCancel(context.Background())
+	defer cancelRelay()
+	relay := events.NewRelay(db, eventPublisher, log, 0, 0)
+	go func() {
+		log.Info("Outbox relay starting", "events_backend", cfg.Events.Backend)
+		if err := relay.Run(relayCtx); err != nil {
+			log.Error("Outbox relay stopped", "error", err)
+		}
+	}()
+
+	queueClient := queue.NewClient(cfg)
+	defer func() {
+		if err := queueClient.Close(); err != nil {
+			log.Error("Error closing queue client", "error", err)
+		}
+	}()
+
+	queueServer := queue.NewServer(cfg, log)
+	queueServer.Handle(queue.TaskPaymentCallback, handlers.NewPaymentCallbackTaskHandler(db, log, queueClient))
+	queueServer.Handle(queue.TaskOrderConfirmation, handlers.NewOrderConfirmationTaskHandler(db, log, queueClient))
+	queueServer.Handle(queue.TaskEmailSend, handlers.NewEmailSendTaskHandler(log))
+	queueServer.Handle(queue.TaskReceiptGenerate, handlers.NewReceiptGenerateTaskHandler(db, log))
+
+	go func() {
+		log.Info("Queue server starting")
+		if err := queueServer.Run(); err != nil {
+			log.Fatal("Queue server failed to start", "error", err)
+		}
+	}()
+
 	healthHandler := handlers.NewHealthHandler(log, db)
 	productHandler := handlers.NewProductHandler(db, log)
 	cartHandler := handlers.NewCartHandler(db, log)
-	orderHandler := handlers.NewOrderHandler(db, log)
-	paymentHandler := handlers.NewPaymentHandler(db, log, cfg)
+	orderHandler := handlers.NewOrderHandler(db, log, queueClient, eventPublisher)
+	paymentHandler := handlers.NewPaymentHandler(db, log, cfg, queueClient, eventPublisher)
 
 	router := mux.NewRouter()
 
@@ -65,11 +125,22 @@ func main() {
 	router.Use(middleware.Security())
 	router.Use(middleware.RateLimiting())
 
-	if cfg.Kong.InternalAuth != "" {
-		router.Use(middleware.KongAuth(cfg.Kong.InternalAuth, cfg.Kong.AllowedIPs))
+	if !cfg.Kong.InternalAuth.Empty() {
+		router.Use(middleware.KongAuth(cfg.Kong.InternalAuth.Reveal(), cfg.Kong.AllowedIPs))
 	}
 
-	setupRoutes(router, healthHandler, productHandler, cartHandler, orderHandler, paymentHandler, cfg)
+	setupRoutes(router, healthHandler, productHandler, cartHandler, orderHandler, paymentHandler, cfg, log)
+
+	reloadCtx, cancelReload := context.WithCancel(context.Background())
+	defer cancelReload()
+	go func() {
+		if err := config.Watch(reloadCtx, cfg, func(reloaded *config.Config) {
+			log.SetLevel(reloaded.LogLevel)
+			log.Info("Configuration reloaded", "log_level", reloaded.LogLevel, "kong_allowed_ips", reloaded.Kong.AllowedIPs)
+		}); err != nil {
+			log.Error("Config watcher stopped", "error", err)
+		}
+	}()
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -99,6 +170,8 @@ func main() {
 		log.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	queueServer.Shutdown()
+
 	log.Info("Server exited")
 }
 
@@ -110,6 +183,7 @@ func setupRoutes(
 	orderHandler *handlers.OrderHandler,
 	paymentHandler *handlers.PaymentHandler,
 	cfg *config.Config,
+	log *logger.Logger,
 ) {
 	api := router.PathPrefix("/api/v1").Subrouter()
 
@@ -124,14 +198,14 @@ func setupRoutes(
 	api.HandleFunc("/categories", productHandler.GetCategories).Methods("GET")
 
 	adminRoutes := api.PathPrefix("/admin").Subrouter()
-	adminRoutes.Use(middleware.Auth(cfg.JWT.Secret))
+	adminRoutes.Use(middleware.Auth(cfg.JWT.Secret.Reveal()))
 	adminRoutes.Use(middleware.RequireRole("admin"))
 	adminRoutes.HandleFunc("/products", productHandler.Create).Methods("POST")
 	adminRoutes.HandleFunc("/products/{id}", productHandler.Update).Methods("PUT")
 	adminRoutes.HandleFunc("/products/{id}", productHandler.Delete).Methods("DELETE")
 
 	cartRoutes := api.PathPrefix("/cart").Subrouter()
-	cartRoutes.Use(middleware.Auth(cfg.JWT.Secret))
+	cartRoutes.Use(middleware.Auth(cfg.JWT.Secret.Reveal()))
 	cartRoutes.HandleFunc("", cartHandler.Get).Methods("GET")
 	cartRoutes.HandleFunc("", cartHandler.AddItem).Methods("POST")
 	cartRoutes.HandleFunc("/items/{id}", cartHandler.UpdateItem).Methods("PUT")
@@ -139,7 +213,7 @@ func setupRoutes(
 	cartRoutes.HandleFunc("/clear", cartHandler.Clear).Methods("DELETE")
 
 	orderRoutes := api.PathPrefix("/orders").Subrouter()
-	orderRoutes.Use(middleware.Auth(cfg.JWT.Secret))
+	orderRoutes.Use(middleware.Auth(cfg.JWT.Secret.Reveal()))
 	orderRoutes.HandleFunc("", orderHandler.Create).Methods("POST")
 	orderRoutes.HandleFunc("", orderHandler.GetUserOrders).Methods("GET")
 	orderRoutes.HandleFunc("/{id}", orderHandler.GetByID).Methods("GET")
@@ -148,16 +222,17 @@ func setupRoutes(
 	paymentRoutes := api.PathPrefix("/payments").Subrouter()
 
 	paymentRoutes.HandleFunc("", paymentHandler.CreatePayment).Methods("POST").Handler(
-		middleware.Auth(cfg.JWT.Secret)(http.HandlerFunc(paymentHandler.CreatePayment)),
+		middleware.Auth(cfg.JWT.Secret.Reveal())(http.HandlerFunc(paymentHandler.CreatePayment)),
 	)
 	paymentRoutes.HandleFunc("/{id}/status", paymentHandler.GetPaymentStatus).Methods("GET").Handler(
-		middleware.Auth(cfg.JWT.Secret)(http.HandlerFunc(paymentHandler.GetPaymentStatus)),
+		middleware.Auth(cfg.JWT.Secret.Reveal())(http.HandlerFunc(paymentHandler.GetPaymentStatus)),
 	)
 
-	api.HandleFunc("/payments/callback", paymentHandler.HandleCallback).Methods("POST")
-
-	api.HandleFunc("/payments/success", paymentHandler.HandleSuccess).Methods("GET")
-	api.HandleFunc("/payments/cancel", paymentHandler.HandleCancel).Methods("GET")
+	paytrailCallbackRoutes := api.PathPrefix("/payments").Subrouter()
+	paytrailCallbackRoutes.Use(middleware.PaytrailSignature(cfg.Paytrail.SecretKey.Reveal(), log))
+	paytrailCallbackRoutes.HandleFunc("/callback", paymentHandler.HandleCallback).Methods("POST")
+	paytrailCallbackRoutes.HandleFunc("/success", paymentHandler.HandleSuccess).Methods("GET")
+	paytrailCallbackRoutes.HandleFunc("/cancel", paymentHandler.HandleCancel).Methods("GET")
 
 	authRoutes := api.PathPrefix("/auth").Subrouter()
 
@@ -193,3 +268,89 @@ func setupRoutes(
 		w.Write([]byte(`{"error": "endpoint not found", "path": "` + r.URL.Path + `"}`))
 	})
 }
+
+// runMigrate handles "migrate up [N]", "migrate down [N]", "migrate
+// version", "migrate force V", and "migrate create NAME". It loads config
+// the same way runServe does, so --database-url/--config-file/CONFIG_PATH
+// work identically for both.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|version|force|create [args]")
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate create NAME")
+		}
+		paths, err := migrations.Create(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", paths)
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load configuration: %w", err)
+	}
+	log := logger.New(cfg.LogLevel)
+
+	db, err := database.Connect(cfg.DatabaseURL, cfg.MaxConnections)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up", "down":
+		steps, err := migrateSteps(args[1:])
+		if err != nil {
+			return err
+		}
+		direction := migrations.Up
+		if args[0] == "down" {
+			direction = migrations.Down
+		}
+		if err := migrations.Run(db, direction, steps); err != nil {
+			return err
+		}
+		log.Info("Migrations applied", "direction", args[0], "steps", steps)
+	case "version":
+		version, dirty, err := migrations.Version(db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+	case "force":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+		if err := migrations.Force(db, version); err != nil {
+			return err
+		}
+		log.Info("Schema version forced", "version", version)
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+// migrateSteps parses the optional step count following "migrate up"/
+// "migrate down". No argument means "every pending migration in that
+// direction", matching migrations.Run's steps <= 0 convention.
+func migrateSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid step count %q: %w", args[0], err)
+	}
+	return steps, nil
+}
@@ -2,8 +2,7 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"strconv"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -15,124 +14,85 @@ type Config struct {
 	DatabaseURL    string        `json:"database_url"`
 	MaxConnections int           `json:"max_connections"`
 	Timeout        time.Duration `json:"timeout"`
+	LogLevel       string        `json:"log_level"`
 
 	Paytrail struct {
-		MerchantID  string `json:"merchant_id"`
-		SecretKey   string `json:"secret_key"`
-		BaseURL     string `json:"base_url"`
-		CallbackURL string `json:"callback_url"`
-		SuccessURL  string `json:"success_url"`
-		CancelURL   string `json:"cancel_url"`
+		MerchantID  string       `json:"merchant_id"`
+		SecretKey   SecretString `json:"secret_key"`
+		BaseURL     string       `json:"base_url"`
+		CallbackURL string       `json:"callback_url"`
+		SuccessURL  string       `json:"success_url"`
+		CancelURL   string       `json:"cancel_url"`
 	} `json:"paytrail"`
 
 	Kong struct {
-		InternalAuth string   `json:"internal_auth"`
-		AllowedIPs   []string `json:"allowed_ips"`
-		AdminAPIURL  string   `json:"admin_api_url"`
-		ServiceURL   string   `json:"service_url"`
+		InternalAuth SecretString `json:"internal_auth"`
+		AllowedIPs   []string     `json:"allowed_ips"`
+		AdminAPIURL  string       `json:"admin_api_url"`
+		ServiceURL   string       `json:"service_url"`
 	} `json:"kong"`
 
 	JWT struct {
-		Secret             string        `json:"secret"`
+		Secret             SecretString  `json:"secret"`
 		AccessTokenExpiry  time.Duration `json:"access_token_expiry"`
 		RefreshTokenExpiry time.Duration `json:"refresh_token_expiry"`
 	} `json:"jwt"`
-}
-
-func Load() (*Config, error) {
-	cfg := &Config{}
-
-	// Server configuration
-	cfg.Port = getEnv("PORT", "8080")
-	cfg.Environment = getEnv("ENVIRONMENT", "development")
-	cfg.DebugMode = getEnvAsBool("DEBUG_MODE", false)
-	cfg.MaxConnections = getEnvAsInt("MAX_CONNECTIONS", 100)
-	cfg.Timeout = getEnvAsDuration("TIMEOUT", 30*time.Second)
-
-	// Database
-	cfg.DatabaseURL = getEnv("DATABASE_URL", "")
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable is required")
-	}
 
-	// Paytrail
-	if err := loadPaytrailConfig(cfg); err != nil {
-		return nil, fmt.Errorf("paytrail configuration error: %w", err)
-	}
+	Redis RedisConfig `json:"redis"`
 
-	// Kong
-	if err := loadKongConfig(cfg); err != nil {
-		return nil, fmt.Errorf("kong configuration error: %w", err)
-	}
+	Database struct {
+		AutoMigrate bool `json:"auto_migrate"`
+	} `json:"database"`
 
-	// JWT
-	if err := loadJWTConfig(cfg); err != nil {
-		return nil, fmt.Errorf("jwt configuration error: %w", err)
-	}
+	Events struct {
+		Backend string `json:"backend"`
+		Stream  string `json:"stream"`
+		MaxLen  int64  `json:"max_len"`
+	} `json:"events"`
 
-	// validate configuration
-	if err := cfg.validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
-	}
-
-	return cfg, nil
+	Queue struct {
+		Concurrency int           `json:"concurrency"`
+		MaxRetry    int           `json:"max_retry"`
+		MinBackoff  time.Duration `json:"min_backoff"`
+		MaxBackoff  time.Duration `json:"max_backoff"`
+	} `json:"queue"`
 }
 
-func loadPaytrailConfig(cfg *Config) error {
-	cfg.Paytrail.MerchantID = getEnv("PAYTRAIL_MERCHANT_ID", "")
-	if cfg.Paytrail.MerchantID == "" {
-		return fmt.Errorf("PAYTRAIL_MERCHANT_ID is required")
-	}
-
-	cfg.Paytrail.SecretKey = getEnv("PAYTRAIL_SECRET_KEY", "")
-	if cfg.Paytrail.SecretKey == "" {
-		return fmt.Errorf("PAYTRAIL_SECRET_KEY is required")
-	}
-
-	cfg.Paytrail.BaseURL = getEnv("PAYTRAIL_BASE_URL", "https://services.paytrail.com")
-	cfg.Paytrail.CallbackURL = getEnv("PAYTRAIL_CALLBACK_URL", "")
-	cfg.Paytrail.SuccessURL = getEnv("PAYTRAIL_SUCCESS_URL", "")
-	cfg.Paytrail.CancelURL = getEnv("PAYTRAIL_CANCEL_URL", "")
-
-	// validate required URLs
-	if cfg.Paytrail.CallbackURL == "" {
-		return fmt.Errorf("PAYTRAIL_CALLBACK_URL is required")
-	}
-	if cfg.Paytrail.SuccessURL == "" {
-		return fmt.Errorf("PAYTRAIL_SUCCESS_URL is required")
-	}
-	if cfg.Paytrail.CancelURL == "" {
-		return fmt.Errorf("PAYTRAIL_CANCEL_URL is required")
-	}
-
-	return nil
+// RedisConfig holds connection settings shared by every Redis-backed
+// subsystem (the job queue and the redis events backend).
+type RedisConfig struct {
+	URL      string `json:"url"`
+	DB       int    `json:"db"`
+	PoolSize int    `json:"pool_size"`
 }
 
-func loadKongConfig(cfg *Config) error {
-	cfg.Kong.InternalAuth = getEnv("KONG_INTERNAL_AUTH", "")
-	if cfg.Kong.InternalAuth == "" {
-		return fmt.Errorf("KONG_INTERNAL_AUTH is required")
+// Addr returns the host:port asynq/go-redis expect, stripping the
+// redis:// (or rediss://) scheme and any credentials from URL.
+func (r RedisConfig) Addr() string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Host == "" {
+		return r.URL
 	}
-
-	cfg.Kong.AllowedIPs = getEnvAsSlice("KONG_ALLOWED_IPS", ",", []string{})
-	cfg.Kong.AdminAPIURL = getEnv("KONG_ADMIN_API_URL", "")
-	cfg.Kong.ServiceURL = getEnv("KONG_SERVICE_URL", "http://localhost:8080")
-
-	return nil
+	return u.Host
 }
 
-func loadJWTConfig(cfg *Config) error {
-	cfg.JWT.Secret = getEnv("JWT_SECRET", "")
-	if cfg.JWT.Secret == "" {
-		return fmt.Errorf("JWT_SECRET is required")
+// Password returns the password embedded in URL's userinfo (redis://
+// [[user]:password@]host:port), or "" if URL has none. Addr strips this
+// same userinfo out of the address it returns, so callers need both.
+func (r RedisConfig) Password() string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.User == nil {
+		return ""
 	}
-
-	cfg.JWT.AccessTokenExpiry = getEnvAsDuration("JWT_ACCESS_TOKEN_EXPIRY", 15*time.Minute)
-	cfg.JWT.RefreshTokenExpiry = getEnvAsDuration("JWT_REFRESH_TOKEN_EXPIRY", 7*24*time.Hour)
-
-	return nil
+	password, _ := u.User.Password()
+	return password
 }
 
+// minProductionSecretLength is the shortest secret (JWT signing key,
+// Paytrail merchant secret, Kong internal auth token) we'll accept once
+// Environment is "production".
+const minProductionSecretLength = 32
+
 func (c *Config) validate() error {
 	// validate environment
 	validEnvs := map[string]bool{
@@ -144,6 +104,14 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid environment: %s (must be development, staging, or production)", c.Environment)
 	}
 
+	validEventBackends := map[string]bool{
+		"memory": true,
+		"redis":  true,
+	}
+	if !validEventBackends[c.Events.Backend] {
+		return fmt.Errorf("invalid events backend: %s (must be memory or redis)", c.Events.Backend)
+	}
+
 	// production-specific validations
 	if c.Environment == "production" {
 		if c.DebugMode {
@@ -152,6 +120,12 @@ func (c *Config) validate() error {
 		if c.JWT.AccessTokenExpiry > time.Hour {
 			return fmt.Errorf("access token expiry too long for production environment")
 		}
+		if err := c.validateProductionSecrets(); err != nil {
+			return err
+		}
+		if err := c.validateProductionCallbackURLs(); err != nil {
+			return err
+		}
 	}
 
 	// validate timeouts
@@ -167,70 +141,40 @@ func (c *Config) validate() error {
 	return nil
 }
 
-// returns true if running in production environment
-func (c *Config) IsProduction() bool {
-	return c.Environment == "production"
-}
-
-// returns true if running in development environment
-func (c *Config) IsDevelopment() bool {
-	return c.Environment == "development"
-}
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsBool(key string, defaultValue bool) bool {
-	strValue := getEnv(key, "")
-	if strValue == "" {
-		return defaultValue
+func (c *Config) validateProductionSecrets() error {
+	secrets := map[string]SecretString{
+		"JWT_SECRET":          c.JWT.Secret,
+		"PAYTRAIL_SECRET_KEY": c.Paytrail.SecretKey,
+		"KONG_INTERNAL_AUTH":  c.Kong.InternalAuth,
 	}
-	boolValue, err := strconv.ParseBool(strValue)
-	if err != nil {
-		fmt.Printf("Warning: Could not parse %s as boolean, using default %v. Error: %v\n", key, defaultValue, err)
-		return defaultValue
+	for name, secret := range secrets {
+		if len(secret.Reveal()) < minProductionSecretLength {
+			return fmt.Errorf("%s must be at least %d characters in production", name, minProductionSecretLength)
+		}
 	}
-	return boolValue
+	return nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	strValue := getEnv(key, "")
-	if strValue == "" {
-		return defaultValue
+func (c *Config) validateProductionCallbackURLs() error {
+	urls := map[string]string{
+		"PAYTRAIL_CALLBACK_URL": c.Paytrail.CallbackURL,
+		"PAYTRAIL_SUCCESS_URL":  c.Paytrail.SuccessURL,
+		"PAYTRAIL_CANCEL_URL":   c.Paytrail.CancelURL,
 	}
-	intValue, err := strconv.Atoi(strValue)
-	if err != nil {
-		fmt.Printf("Warning: Could not parse %s as integer, using default %d. Error: %v\n", key, defaultValue, err)
-		return defaultValue
+	for name, rawURL := range urls {
+		if !strings.HasPrefix(rawURL, "https://") {
+			return fmt.Errorf("%s must use https in production, got %q", name, rawURL)
+		}
 	}
-	return intValue
+	return nil
 }
 
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	strValue := getEnv(key, "")
-	if strValue == "" {
-		return defaultValue
-	}
-	durationValue, err := time.ParseDuration(strValue)
-	if err != nil {
-		fmt.Printf("Warning: Could not parse %s as duration, using default %s. Error: %v\n", key, defaultValue.String(), err)
-		return defaultValue
-	}
-	return durationValue
+// returns true if running in production environment
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
 }
 
-func getEnvAsSlice(key, delimiter string, defaultValue []string) []string {
-	strValue := getEnv(key, "")
-	if strValue == "" {
-		return defaultValue
-	}
-	parts := strings.Split(strValue, delimiter)
-	for i, part := range parts {
-		parts[i] = strings.TrimSpace(part)
-	}
-	return parts
+// returns true if running in development environment
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
 }
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	flag "github.com/namsral/flag"
+	"gopkg.in/yaml.v3"
+)
+
+// envConfigPath names the environment variable that points at the YAML
+// config file, mirroring the --config-file flag below it in precedence.
+const envConfigPath = "CONFIG_PATH"
+
+// configFileFlag names the flag that carries the YAML config file path.
+// It deliberately isn't "config": namsral/flag reserves that name for its
+// own built-in config-file support and, on Parse, re-reads whatever path
+// it's given as its own key=value format — which blows up on our YAML.
+const configFileFlag = "config-file"
+
+// loadedConfigPath remembers the YAML file path the last Load() call
+// resolved, so Watch knows what to watch without the caller re-deriving
+// it from argv.
+var loadedConfigPath string
+
+// Load builds a Config by layering, lowest precedence first: built-in
+// defaults, a YAML config file (--config-file / CONFIG_PATH), environment
+// variables, and command-line flags. Flags win every tie, matching the
+// namsral/flag convention the rest of our tooling uses.
+func Load() (*Config, error) {
+	path := configPath(os.Args[1:])
+	file, err := loadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file error: %w", err)
+	}
+	loadedConfigPath = path
+
+	cfg := &Config{}
+	fs := flag.NewFlagSet("sb-module", flag.ContinueOnError)
+
+	fs.String(configFileFlag, "", "path to a YAML config file (env: CONFIG_PATH)")
+
+	fs.StringVar(&cfg.Port, "port", strOr(file.Port, "8080"), "HTTP listen port")
+	fs.StringVar(&cfg.Environment, "environment", strOr(file.Environment, "development"), "development, staging, or production")
+	fs.BoolVar(&cfg.DebugMode, "debug-mode", boolOr(file.DebugMode, false), "enable verbose debug logging")
+	fs.IntVar(&cfg.MaxConnections, "max-connections", intOr(file.MaxConnections, 100), "max database connections")
+	fs.DurationVar(&cfg.Timeout, "timeout", parseDurationOr(file.Timeout, 30*time.Second), "HTTP server read/write timeout")
+	fs.StringVar(&cfg.LogLevel, "log-level", strOr(file.LogLevel, ""), "debug, info, warn, or error (defaults to environment-derived level)")
+	fs.StringVar(&cfg.DatabaseURL, "database-url", file.DatabaseURL, "Postgres connection string")
+
+	fs.StringVar(&cfg.Paytrail.MerchantID, "paytrail-merchant-id", file.Paytrail.MerchantID, "Paytrail merchant ID")
+	paytrailSecretKey := fs.String("paytrail-secret-key", file.Paytrail.SecretKey, "Paytrail merchant secret")
+	fs.StringVar(&cfg.Paytrail.BaseURL, "paytrail-base-url", strOr(file.Paytrail.BaseURL, "https://services.paytrail.com"), "Paytrail API base URL")
+	fs.StringVar(&cfg.Paytrail.CallbackURL, "paytrail-callback-url", file.Paytrail.CallbackURL, "Paytrail webhook callback URL")
+	fs.StringVar(&cfg.Paytrail.SuccessURL, "paytrail-success-url", file.Paytrail.SuccessURL, "Paytrail success redirect URL")
+	fs.StringVar(&cfg.Paytrail.CancelURL, "paytrail-cancel-url", file.Paytrail.CancelURL, "Paytrail cancel redirect URL")
+
+	kongInternalAuth := fs.String("kong-internal-auth", file.Kong.InternalAuth, "Kong internal auth token")
+	allowedIPs := newStringSliceValue(file.Kong.AllowedIPs)
+	fs.Var(allowedIPs, "kong-allowed-ips", "comma-separated IPs allowed through Kong auth")
+	fs.StringVar(&cfg.Kong.AdminAPIURL, "kong-admin-api-url", file.Kong.AdminAPIURL, "Kong admin API URL")
+	fs.StringVar(&cfg.Kong.ServiceURL, "kong-service-url", strOr(file.Kong.ServiceURL, "http://localhost:8080"), "Kong upstream service URL")
+
+	jwtSecret := fs.String("jwt-secret", file.JWT.Secret, "JWT signing secret")
+	fs.DurationVar(&cfg.JWT.AccessTokenExpiry, "jwt-access-token-expiry", parseDurationOr(file.JWT.AccessTokenExpiry, 15*time.Minute), "access token lifetime")
+	fs.DurationVar(&cfg.JWT.RefreshTokenExpiry, "jwt-refresh-token-expiry", parseDurationOr(file.JWT.RefreshTokenExpiry, 7*24*time.Hour), "refresh token lifetime")
+
+	fs.StringVar(&cfg.Redis.URL, "redis-url", strOr(file.Redis.URL, "redis://localhost:6379"), "Redis connection URL")
+	fs.IntVar(&cfg.Redis.DB, "redis-db", file.Redis.DB, "Redis logical DB index")
+	fs.IntVar(&cfg.Redis.PoolSize, "redis-pool-size", intOr(file.Redis.PoolSize, 10), "Redis connection pool size")
+
+	autoMigrate := fs.Bool("database-auto-migrate", boolOr(file.Database.AutoMigrate, true), "run pending migrations automatically before serve starts (default: true in development, false in production)")
+
+	fs.StringVar(&cfg.Events.Backend, "events-backend", strOr(file.Events.Backend, "memory"), "domain event publisher backend: memory or redis")
+	fs.StringVar(&cfg.Events.Stream, "events-stream", strOr(file.Events.Stream, "shockbliss-events"), "Redis Streams key the redis events backend publishes to")
+	fs.Int64Var(&cfg.Events.MaxLen, "events-max-len", int64Or(file.Events.MaxLen, 100_000), "approximate max length the redis events backend trims the stream to")
+
+	fs.IntVar(&cfg.Queue.Concurrency, "queue-concurrency", intOr(file.Queue.Concurrency, 10), "asynq worker concurrency")
+	fs.IntVar(&cfg.Queue.MaxRetry, "queue-max-retry", intOr(file.Queue.MaxRetry, 10), "max task retries before dead-lettering")
+	fs.DurationVar(&cfg.Queue.MinBackoff, "queue-min-backoff", parseDurationOr(file.Queue.MinBackoff, 5*time.Second), "minimum retry backoff")
+	fs.DurationVar(&cfg.Queue.MaxBackoff, "queue-max-backoff", parseDurationOr(file.Queue.MaxBackoff, 30*time.Minute), "maximum retry backoff")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("parse configuration: %w", err)
+	}
+
+	cfg.Paytrail.SecretKey = SecretString(*paytrailSecretKey)
+	cfg.Kong.InternalAuth = SecretString(*kongInternalAuth)
+	cfg.JWT.Secret = SecretString(*jwtSecret)
+	cfg.Kong.AllowedIPs = allowedIPs.values
+
+	cfg.Database.AutoMigrate = *autoMigrate
+	if file.Database.AutoMigrate == nil && !explicitlySet(fs, "database-auto-migrate") {
+		cfg.Database.AutoMigrate = !cfg.IsProduction()
+	}
+
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = deriveLogLevel(cfg.Environment, cfg.DebugMode)
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL (or --database-url) is required")
+	}
+	if cfg.Paytrail.MerchantID == "" {
+		return nil, fmt.Errorf("PAYTRAIL_MERCHANT_ID (or --paytrail-merchant-id) is required")
+	}
+	if cfg.Paytrail.SecretKey.Empty() {
+		return nil, fmt.Errorf("PAYTRAIL_SECRET_KEY (or --paytrail-secret-key) is required")
+	}
+	if cfg.Paytrail.CallbackURL == "" {
+		return nil, fmt.Errorf("PAYTRAIL_CALLBACK_URL (or --paytrail-callback-url) is required")
+	}
+	if cfg.Paytrail.SuccessURL == "" {
+		return nil, fmt.Errorf("PAYTRAIL_SUCCESS_URL (or --paytrail-success-url) is required")
+	}
+	if cfg.Paytrail.CancelURL == "" {
+		return nil, fmt.Errorf("PAYTRAIL_CANCEL_URL (or --paytrail-cancel-url) is required")
+	}
+	if cfg.Kong.InternalAuth.Empty() {
+		return nil, fmt.Errorf("KONG_INTERNAL_AUTH (or --kong-internal-auth) is required")
+	}
+	if cfg.JWT.Secret.Empty() {
+		return nil, fmt.Errorf("JWT_SECRET (or --jwt-secret) is required")
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func deriveLogLevel(environment string, debugMode bool) string {
+	if environment == "production" {
+		return "warn"
+	}
+	if debugMode {
+		return "debug"
+	}
+	return "info"
+}
+
+// configPath finds the YAML config file path before the main flag set is
+// built, since the file's values become that flag set's defaults. It
+// checks CONFIG_PATH first, then scans args for --config-file/-config-file,
+// so the file can't accidentally shadow an explicit flag.
+func configPath(args []string) string {
+	if p := os.Getenv(envConfigPath); p != "" {
+		return p
+	}
+	flagLong := "--" + configFileFlag
+	flagShort := "-" + configFileFlag
+	for i, arg := range args {
+		switch {
+		case arg == flagShort || arg == flagLong:
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		case strings.HasPrefix(arg, flagShort+"="):
+			return strings.TrimPrefix(arg, flagShort+"=")
+		case strings.HasPrefix(arg, flagLong+"="):
+			return strings.TrimPrefix(arg, flagLong+"=")
+		}
+	}
+	return ""
+}
+
+func strOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
+
+func intOr(v, fallback int) int {
+	if v == 0 {
+		return fallback
+	}
+	return v
+}
+
+func int64Or(v, fallback int64) int64 {
+	if v == 0 {
+		return fallback
+	}
+	return v
+}
+
+func boolOr(v *bool, fallback bool) bool {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// explicitlySet reports whether name was passed on the command line,
+// distinguishing an explicit flag from one that merely kept its
+// environment-derived default.
+func explicitlySet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parseDurationOr parses a YAML-file duration string (e.g. "30s"),
+// falling back to fallback if it's empty or malformed.
+func parseDurationOr(v string, fallback time.Duration) time.Duration {
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// fileConfig mirrors Config with plain, zero-value-means-unset types so a
+// YAML file only needs to specify the fields it overrides. Durations are
+// strings ("30s") rather than time.Duration so yaml.v3 can decode them
+// without a custom UnmarshalYAML.
+type fileConfig struct {
+	Port           string `yaml:"port"`
+	Environment    string `yaml:"environment"`
+	DebugMode      *bool  `yaml:"debug_mode"`
+	DatabaseURL    string `yaml:"database_url"`
+	MaxConnections int    `yaml:"max_connections"`
+	Timeout        string `yaml:"timeout"`
+	LogLevel       string `yaml:"log_level"`
+
+	Paytrail struct {
+		MerchantID  string `yaml:"merchant_id"`
+		SecretKey   string `yaml:"secret_key"`
+		BaseURL     string `yaml:"base_url"`
+		CallbackURL string `yaml:"callback_url"`
+		SuccessURL  string `yaml:"success_url"`
+		CancelURL   string `yaml:"cancel_url"`
+	} `yaml:"paytrail"`
+
+	Kong struct {
+		InternalAuth string   `yaml:"internal_auth"`
+		AllowedIPs   []string `yaml:"allowed_ips"`
+		AdminAPIURL  string   `yaml:"admin_api_url"`
+		ServiceURL   string   `yaml:"service_url"`
+	} `yaml:"kong"`
+
+	JWT struct {
+		Secret             string `yaml:"secret"`
+		AccessTokenExpiry  string `yaml:"access_token_expiry"`
+		RefreshTokenExpiry string `yaml:"refresh_token_expiry"`
+	} `yaml:"jwt"`
+
+	Redis struct {
+		URL      string `yaml:"url"`
+		DB       int    `yaml:"db"`
+		PoolSize int    `yaml:"pool_size"`
+	} `yaml:"redis"`
+
+	Database struct {
+		AutoMigrate *bool `yaml:"auto_migrate"`
+	} `yaml:"database"`
+
+	Events struct {
+		Backend string `yaml:"backend"`
+		Stream  string `yaml:"stream"`
+		MaxLen  int64  `yaml:"max_len"`
+	} `yaml:"events"`
+
+	Queue struct {
+		Concurrency int    `yaml:"concurrency"`
+		MaxRetry    int    `yaml:"max_retry"`
+		MinBackoff  string `yaml:"min_backoff"`
+		MaxBackoff  string `yaml:"max_backoff"`
+	} `yaml:"queue"`
+}
+
+// loadFile reads and parses the YAML config at path. A missing path (no
+// --config-file/CONFIG_PATH given) or missing file is not an error: Load
+// falls back entirely to flags and environment variables.
+func loadFile(path string) (fileConfig, error) {
+	var fc fileConfig
+	if path == "" {
+		return fc, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fc, nil
+		}
+		return fc, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return fc, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return fc, nil
+}
+
+// stringSliceValue implements flag.Value for a comma-separated list,
+// pre-seeded with a file-provided default the way the scalar StringVar
+// calls above are.
+type stringSliceValue struct {
+	values []string
+}
+
+func newStringSliceValue(defaultValue []string) *stringSliceValue {
+	return &stringSliceValue{values: defaultValue}
+}
+
+func (s *stringSliceValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(s.values, ",")
+}
+
+func (s *stringSliceValue) Set(raw string) error {
+	parts := strings.Split(raw, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	s.values = parts
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+// SecretString wraps a configuration value that must never be written to
+// logs, error messages, or serialized config dumps (JWT signing secrets,
+// the Paytrail merchant secret, the Kong internal auth token). It renders
+// as "***" everywhere except Reveal, so a stray %v, json.Marshal, or zap
+// field can't leak it by accident.
+type SecretString string
+
+// String implements fmt.Stringer, so %v/%s and zap's reflection-based
+// field encoder both print "***" instead of the secret.
+func (s SecretString) String() string {
+	return "***"
+}
+
+// MarshalJSON implements json.Marshaler, redacting the value wherever
+// Config (or anything holding a SecretString) is serialized.
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"***"`), nil
+}
+
+// Reveal returns the underlying secret. Use it only at the point the
+// value is actually needed (signing, comparison) — never pass it to a
+// logger or error message.
+func (s SecretString) Reveal() string {
+	return string(s)
+}
+
+// Empty reports whether the secret was never set.
+func (s SecretString) Empty() bool {
+	return s == ""
+}
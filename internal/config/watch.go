@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// current holds the most recently loaded Config so handlers that read via
+// Current() see hot-reloaded values without needing a restart.
+var current atomic.Pointer[Config]
+
+// Current returns the most recently loaded Config. Load does not store
+// into it; before the first Watch call it returns nil, so callers should thread cfg through
+// constructors as usual and only fall back to Current() for values that
+// can change at runtime (log level, Kong allowed IPs, rate limits).
+func Current() *Config {
+	return current.Load()
+}
+
+// Watch reloads cfg whenever the file at configPath changes on disk and
+// stores the result behind Current(), then invokes onChange with it.
+// Only non-structural fields are meant to change between reloads (log
+// level, Kong allowed IPs, timeouts); Watch does not restart anything
+// that was wired up using the original Config, such as the database pool
+// or the HTTP server's listener.
+//
+// Watch blocks until ctx is done or the watcher fails to start, so call
+// it in its own goroutine. It stores the initial cfg immediately so
+// Current() is usable right away even before the first file change.
+func Watch(ctx context.Context, cfg *Config, onChange func(*Config)) error {
+	current.Store(cfg)
+
+	path := loadedConfigPath
+	if path == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// Watch the parent directory rather than path itself. Kubernetes
+	// ConfigMap mounts (and most atomic-config-write tooling) publish an
+	// update by renaming a new "..data" symlink target into place, not by
+	// writing path in place — an inotify watch on path's original inode
+	// never sees that swap. Watching the directory and filtering events
+	// down to this file's name catches the Create/Rename the swap
+	// produces as well as a plain in-place Write.
+	dir := filepath.Dir(path)
+	name := filepath.Base(path)
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Base(event.Name) != name {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			reloaded, err := Load()
+			if err != nil {
+				continue
+			}
+			current.Store(reloaded)
+			if onChange != nil {
+				onChange(reloaded)
+			}
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// migrationFilePattern matches this package's "NNNN_name.up.sql" /
+// "NNNN_name.down.sql" naming convention.
+var migrationFilePattern = regexp.MustCompile(`^(\d{4})_.*\.(up|down)\.sql$`)
+
+// Create writes an empty up/down migration pair named
+// "NNNN_name.{up,down}.sql" into this package's directory, numbered one
+// past the highest sequence among the files in that directory on disk
+// (not the set embedded in the running binary). It returns the two
+// created file paths. The binary must be rebuilt (go build) before the
+// new pair takes effect, since migrations are loaded from embed.FS.
+func Create(name string) ([]string, error) {
+	dir, err := sourceDir()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := nextSequence(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	if slug == "" {
+		return nil, fmt.Errorf("migrations: create: name must not be empty")
+	}
+
+	base := fmt.Sprintf("%04d_%s", next, slug)
+	upPath := filepath.Join(dir, base+".up.sql")
+	downPath := filepath.Join(dir, base+".down.sql")
+
+	for _, path := range []string{upPath, downPath} {
+		if err := os.WriteFile(path, []byte("-- "+slug+"\n"), 0o644); err != nil {
+			return nil, fmt.Errorf("migrations: create: write %s: %w", path, err)
+		}
+	}
+
+	return []string{upPath, downPath}, nil
+}
+
+func nextSequence(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("migrations: create: read %s: %w", dir, err)
+	}
+
+	highest := 0
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		if n > highest {
+			highest = n
+		}
+	}
+
+	return highest + 1, nil
+}
+
+// sourceDir locates this package's directory on disk so Create can write
+// next to the embedded migrations, regardless of the caller's cwd.
+func sourceDir() (string, error) {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return "", fmt.Errorf("migrations: create: could not determine source directory")
+	}
+	return filepath.Dir(file), nil
+}
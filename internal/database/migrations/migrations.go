@@ -0,0 +1,171 @@
+// Package migrations embeds the SQL schema migrations and drives
+// golang-migrate against them using the embedded iofs source and the
+// database/sql postgres driver.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.up.sql *.down.sql
+var files embed.FS
+
+// Direction selects which half of a migration pair to apply.
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+func newMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("migrations: postgres driver: %w", err)
+	}
+
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrations: source: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: new: %w", err)
+	}
+
+	return m, nil
+}
+
+// Run applies steps pending migrations in direction. steps <= 0 means
+// "apply every pending migration in that direction". ErrNoChange (the
+// schema was already at the target) is not treated as an error.
+func Run(db *sql.DB, direction Direction, steps int) error {
+	m, err := newMigrate(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if steps <= 0 {
+		if direction == Up {
+			err = m.Up()
+		} else {
+			err = m.Down()
+		}
+	} else {
+		if direction == Down {
+			steps = -steps
+		}
+		err = m.Steps(steps)
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrations: %s: %w", direction, err)
+	}
+	return nil
+}
+
+// Force sets the recorded schema version without running its migration
+// body. Use it to clear the "dirty" flag golang-migrate leaves behind
+// after a migration fails partway through.
+func Force(db *sql.DB, version int) error {
+	m, err := newMigrate(db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("migrations: force %d: %w", version, err)
+	}
+	return nil
+}
+
+// Version reports the schema's current migration version and whether the
+// previous migration left it dirty. A schema with no migrations applied
+// yet reports version 0, dirty false, nil error.
+func Version(db *sql.DB) (uint, bool, error) {
+	m, err := newMigrate(db)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	v, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrations: version: %w", err)
+	}
+	return v, dirty, nil
+}
+
+// LatestVersion returns the highest migration version embedded in this
+// binary, parsed from its *.up.sql filenames. It's what the running
+// schema version is compared against to answer "does the schema match
+// the binary".
+func LatestVersion() (uint, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return 0, fmt.Errorf("migrations: read embedded dir: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !ok {
+			continue
+		}
+		numPart, _, _ := strings.Cut(name, "_")
+		n, err := strconv.ParseUint(numPart, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migrations: parse version from %q: %w", entry.Name(), err)
+		}
+		if uint(n) > latest {
+			latest = uint(n)
+		}
+	}
+	return latest, nil
+}
+
+// Status is the schema-version snapshot a readiness check reports:
+// whether the running database is on the migration version this binary
+// was built against, so Kubernetes can hold a pod out of rotation until
+// its schema catches up.
+type Status struct {
+	CurrentVersion uint `json:"current_version"`
+	LatestVersion  uint `json:"latest_version"`
+	Dirty          bool `json:"dirty"`
+	Ready          bool `json:"ready"`
+}
+
+// ReadinessStatus reports db's migration Status for a readiness endpoint
+// to surface. Ready is true only when the schema is at LatestVersion and
+// not dirty.
+func ReadinessStatus(db *sql.DB) (Status, error) {
+	current, dirty, err := Version(db)
+	if err != nil {
+		return Status{}, err
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{
+		CurrentVersion: current,
+		LatestVersion:  latest,
+		Dirty:          dirty,
+		Ready:          !dirty && current == latest,
+	}, nil
+}
@@ -0,0 +1,80 @@
+// Package events defines the domain-event envelope emitted by the
+// order/payment/cart flows and the Publisher/Subscriber interfaces
+// consumers of those events depend on, independent of which transport
+// backs them (see memory.go and redis.go).
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Topic names. These double as the wire contract between publishers and
+// subscribers, so changing one is a breaking change for any external
+// consumer.
+const (
+	TopicOrderCreated     = "order.created"
+	TopicPaymentSucceeded = "payment.succeeded"
+	TopicPaymentFailed    = "payment.failed"
+	TopicCartAbandoned    = "cart.abandoned"
+)
+
+// Event is the versioned envelope every topic is published as. Payload is
+// left as json.RawMessage so the envelope can be stored (outbox table) and
+// routed (Redis Streams) without the transport needing to know every
+// concrete payload type.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	TraceID    string          `json:"trace_id,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// OrderCreatedPayload is Event.Payload for TopicOrderCreated.
+type OrderCreatedPayload struct {
+	OrderID    string `json:"order_id"`
+	UserID     string `json:"user_id"`
+	TotalCents int64  `json:"total_cents"`
+}
+
+// PaymentSucceededPayload is Event.Payload for TopicPaymentSucceeded.
+type PaymentSucceededPayload struct {
+	PaymentID     string `json:"payment_id"`
+	OrderID       string `json:"order_id"`
+	TransactionID string `json:"transaction_id"`
+	AmountCents   int64  `json:"amount_cents"`
+}
+
+// PaymentFailedPayload is Event.Payload for TopicPaymentFailed.
+type PaymentFailedPayload struct {
+	PaymentID     string `json:"payment_id"`
+	OrderID       string `json:"order_id"`
+	TransactionID string `json:"transaction_id"`
+	Reason        string `json:"reason"`
+}
+
+// CartAbandonedPayload is Event.Payload for TopicCartAbandoned.
+type CartAbandonedPayload struct {
+	CartID string `json:"cart_id"`
+	UserID string `json:"user_id"`
+}
+
+// New builds an Event envelope around payload, stamping a fresh ID and the
+// current time. traceID may be empty.
+func New(topic string, payload any, traceID string) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("events: marshal %s payload: %w", topic, err)
+	}
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       topic,
+		OccurredAt: time.Now().UTC(),
+		TraceID:    traceID,
+		Payload:    data,
+	}, nil
+}
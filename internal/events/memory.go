@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// memorySubBuffer is how many unread events a single subscriber channel
+// holds before Publish starts dropping for it. It's generous enough for
+// tests and local development, where MemoryBus is mainly used; production
+// traffic should use the Redis Streams backend instead.
+const memorySubBuffer = 64
+
+// MemoryBus is an in-process Publisher/Subscriber backed by fan-out
+// channels, modeled on the in-memory queue backend described in the
+// Wormhole docs. It never touches the network, so it's the default for
+// tests and for EVENTS_BACKEND=memory in local development.
+type MemoryBus struct {
+	mu   sync.RWMutex
+	subs map[string][]chan Event
+}
+
+// NewMemoryBus builds an empty MemoryBus.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{subs: make(map[string][]chan Event)}
+}
+
+// Publish fans evt out to every subscriber currently registered for
+// topic. A subscriber whose buffer is full has the event dropped for it
+// rather than blocking the publisher, matching at-most-once, best-effort
+// delivery for an in-memory bus with no persistence.
+func (b *MemoryBus) Publish(ctx context.Context, topic string, evt Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subs[topic] {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new channel for topic and returns it. The channel
+// is closed and deregistered once ctx is done.
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string) (<-chan Event, error) {
+	ch := make(chan Event, memorySubBuffer)
+
+	b.mu.Lock()
+	b.subs[topic] = append(b.subs[topic], ch)
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(topic, ch)
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+func (b *MemoryBus) unsubscribe(topic string, ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[topic]
+	for i, existing := range subs {
+		if existing == ch {
+			b.subs[topic] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sb-module/pkg/logger"
+)
+
+// defaultRelayInterval and defaultRelayBatchSize are NewRelay's fallbacks
+// for a zero interval/batchSize.
+const (
+	defaultRelayInterval  = 2 * time.Second
+	defaultRelayBatchSize = 100
+)
+
+// AppendOutbox inserts evt's envelope into event_outbox using tx, so the
+// insert commits atomically with whatever row tx already changed (an
+// order, a payment, a cart). The event reaches Publisher later, out of
+// band, via Relay — never directly from the request path — so a publish
+// can never be lost between a commit and delivery.
+func AppendOutbox(ctx context.Context, tx *sql.Tx, topic string, payload any, traceID string) (Event, error) {
+	evt, err := New(topic, payload, traceID)
+	if err != nil {
+		return Event{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_outbox (event_id, topic, payload, trace_id, occurred_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, evt.ID, evt.Type, []byte(evt.Payload), evt.TraceID, evt.OccurredAt)
+	if err != nil {
+		return Event{}, fmt.Errorf("events: insert outbox row for %s: %w", topic, err)
+	}
+
+	return evt, nil
+}
+
+// Relay polls event_outbox for unpublished rows and publishes them via
+// Publisher, retrying on the next poll whatever failed to publish or
+// crashed mid-batch rather than dropping it.
+type Relay struct {
+	db        *sql.DB
+	publisher Publisher
+	log       *logger.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+// NewRelay builds a Relay. interval <= 0 uses defaultRelayInterval;
+// batchSize <= 0 uses defaultRelayBatchSize.
+func NewRelay(db *sql.DB, publisher Publisher, log *logger.Logger, interval time.Duration, batchSize int) *Relay {
+	if interval <= 0 {
+		interval = defaultRelayInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+	return &Relay{db: db, publisher: publisher, log: log, interval: interval, batchSize: batchSize}
+}
+
+// Run polls on Relay's interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.drain(ctx); err != nil {
+				r.log.Error("Outbox relay drain failed", "error", err)
+			}
+		}
+	}
+}
+
+// outboxRow pairs an unpublished event_outbox row's primary key with the
+// Event it decodes to, so drain can mark it published by id after a
+// successful Publish.
+type outboxRow struct {
+	id    int64
+	event Event
+}
+
+// drain claims a batch of unpublished rows with FOR UPDATE SKIP LOCKED
+// before publishing them, all within one transaction. SKIP LOCKED is what
+// makes this safe to run from more than one replica: a row already
+// claimed by another replica's in-flight drain is invisible to this
+// query rather than something this call blocks on or re-publishes, so
+// replicas partition the backlog instead of all publishing every row
+// every interval.
+func (r *Relay) drain(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("events: begin outbox tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, event_id, topic, payload, trace_id, occurred_at
+		FROM event_outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, r.batchSize)
+	if err != nil {
+		return fmt.Errorf("events: query outbox: %w", err)
+	}
+
+	var pending []outboxRow
+	for rows.Next() {
+		var (
+			row     outboxRow
+			payload []byte
+		)
+		if err := rows.Scan(&row.id, &row.event.ID, &row.event.Type, &payload, &row.event.TraceID, &row.event.OccurredAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("events: scan outbox row: %w", err)
+		}
+		row.event.Payload = json.RawMessage(payload)
+		pending = append(pending, row)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("events: iterate outbox rows: %w", err)
+	}
+	rows.Close()
+
+	for _, row := range pending {
+		if err := r.publisher.Publish(ctx, row.event.Type, row.event); err != nil {
+			r.log.Error("Outbox relay publish failed", "topic", row.event.Type, "event_id", row.event.ID, "error", err)
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE event_outbox SET published_at = now() WHERE id = $1`, row.id); err != nil {
+			return fmt.Errorf("events: mark outbox row %d published: %w", row.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("events: commit outbox tx: %w", err)
+	}
+	return nil
+}
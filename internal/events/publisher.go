@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"sb-module/internal/config"
+)
+
+// Publisher fans an Event out to subscribers of topic. Implementations
+// must be safe for concurrent use, since the outbox Relay and request
+// handlers may publish at the same time.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, evt Event) error
+}
+
+// Subscriber hands back a channel of Events published to topic.
+// Implementations close the channel when ctx is done.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string) (<-chan Event, error)
+}
+
+// NewPublisher builds the Publisher named by cfg.Events.Backend
+// ("memory" or "redis").
+func NewPublisher(cfg *config.Config) (Publisher, error) {
+	switch cfg.Events.Backend {
+	case "", "memory":
+		return NewMemoryBus(), nil
+	case "redis":
+		return NewRedisPublisher(cfg), nil
+	default:
+		return nil, fmt.Errorf("events: unknown backend %q (want memory or redis)", cfg.Events.Backend)
+	}
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"sb-module/internal/config"
+)
+
+// eventField is the stream entry field name Publish writes the marshaled
+// Event envelope into and Subscribe reads it back from.
+const eventField = "event"
+
+// redisSubscribeRetryDelay is how long Subscribe waits after a failed
+// XREAD before retrying, so an unreachable Redis produces a steady trickle
+// of reconnect attempts instead of a busy loop hammering the connection pool.
+const redisSubscribeRetryDelay = 2 * time.Second
+
+// RedisPublisher publishes Events onto a single Redis Stream, trimmed to
+// approximately MaxLen entries with XADD's "~" approximate trim so the
+// stream doesn't grow unbounded. The topic argument to Publish is written
+// onto the stream entry rather than used as the stream key, since a
+// single stream per environment is what a Redis Streams consumer group
+// expects; Subscribe filters by Event.Type client-side.
+type RedisPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisPublisher builds a RedisPublisher from the Redis and Events
+// sections of cfg.
+func NewRedisPublisher(cfg *config.Config) *RedisPublisher {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr(),
+		Password: cfg.Redis.Password(),
+		DB:       cfg.Redis.DB,
+		PoolSize: cfg.Redis.PoolSize,
+	})
+	return &RedisPublisher{
+		client: client,
+		stream: cfg.Events.Stream,
+		maxLen: cfg.Events.MaxLen,
+	}
+}
+
+// Publish appends evt to the stream. topic is not otherwise used: it's
+// carried as evt.Type, which is what Subscribe filters on.
+func (p *RedisPublisher) Publish(ctx context.Context, topic string, evt Event) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("events: marshal %s envelope: %w", topic, err)
+	}
+
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]any{eventField: data},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("events: XADD %s: %w", p.stream, err)
+	}
+	return nil
+}
+
+// Subscribe polls the stream from its tail and forwards Events whose Type
+// matches topic. It blocks in a background goroutine until ctx is done.
+func (p *RedisPublisher) Subscribe(ctx context.Context, topic string) (<-chan Event, error) {
+	out := make(chan Event, memorySubBuffer)
+
+	go func() {
+		defer close(out)
+		lastID := "$"
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			results, err := p.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{p.stream, lastID},
+				Block:   0,
+				Count:   100,
+			}).Result()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case <-time.After(redisSubscribeRetryDelay):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			for _, stream := range results {
+				for _, msg := range stream.Messages {
+					lastID = msg.ID
+					evt, err := decodeStreamEvent(msg.Values)
+					if err != nil || evt.Type != topic {
+						continue
+					}
+					select {
+					case out <- evt:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func decodeStreamEvent(values map[string]any) (Event, error) {
+	raw, ok := values[eventField].(string)
+	if !ok {
+		return Event{}, fmt.Errorf("events: stream entry missing %q field", eventField)
+	}
+	var evt Event
+	if err := json.Unmarshal([]byte(raw), &evt); err != nil {
+		return Event{}, fmt.Errorf("events: unmarshal envelope: %w", err)
+	}
+	return evt, nil
+}
@@ -0,0 +1,122 @@
+// Package handlers holds the HTTP and queue.Handler entry points that sit
+// directly on top of the database, translating requests/tasks into SQL
+// and queue/event side effects. This file covers only the Paytrail
+// callback path; the rest of PaymentHandler (CreatePayment,
+// GetPaymentStatus, HandleSuccess, HandleCancel) and the other handler
+// types (Health, Product, Cart, Order) live outside this change.
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"sb-module/internal/config"
+	"sb-module/internal/events"
+	"sb-module/internal/middleware"
+	"sb-module/internal/queue"
+	"sb-module/pkg/logger"
+)
+
+// Paytrail's checkout-transaction-id/checkout-reference/checkout-status
+// query parameters, sent on the callback, success, and cancel redirects.
+const (
+	paramTransactionID = "checkout-transaction-id"
+	paramOrderID       = "checkout-reference"
+	paramStatus        = "checkout-status"
+)
+
+// PaymentHandler serves the payment HTTP routes. HandleCallback is the
+// only method implemented here; cfg and eventPublisher are threaded
+// through for the rest of the type's methods (out of scope for this
+// change) and aren't read by HandleCallback itself, since the actual
+// payment/order state transition — and the event it emits — happens
+// asynchronously in the PaymentCallbackTaskHandler this enqueues into.
+type PaymentHandler struct {
+	db        *sql.DB
+	log       *logger.Logger
+	cfg       *config.Config
+	queue     *queue.Client
+	publisher events.Publisher
+}
+
+// NewPaymentHandler builds a PaymentHandler.
+func NewPaymentHandler(db *sql.DB, log *logger.Logger, cfg *config.Config, queueClient *queue.Client, publisher events.Publisher) *PaymentHandler {
+	return &PaymentHandler{db: db, log: log, cfg: cfg, queue: queueClient, publisher: publisher}
+}
+
+// HandleCallback is Paytrail's webhook: the PaytrailSignature middleware
+// has already verified the request before this runs. It persists one
+// payment_events row per transaction (unique on transaction_id, so a
+// redelivered webhook's insert is a no-op) and enqueues a
+// TaskPaymentCallback to do the actual state transition on every
+// delivery, not only the one that won the insert: EnqueuePaymentCallback
+// dedupes on TransactionID itself, so this is the layer that retries an
+// enqueue a prior delivery attempted but that never reached Redis (pool
+// exhaustion, a blip) — without this, that delivery's payment_events row
+// would sit with payment_id permanently NULL and no task ever scheduled,
+// since a later redelivery would otherwise see the row already exists
+// and assume the work was already queued. It always returns 200 once the
+// event is durably recorded and the enqueue succeeds, since Paytrail
+// retries on anything else.
+func (h *PaymentHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := middleware.LoggerFromContext(ctx, h.log)
+	query := r.URL.Query()
+
+	transactionID := query.Get(paramTransactionID)
+	orderID := query.Get(paramOrderID)
+	status := query.Get(paramStatus)
+
+	if transactionID == "" || orderID == "" || status == "" {
+		log.Warn("Paytrail callback missing required query parameters", "query", query.Encode())
+		http.Error(w, `{"error": "missing required parameters"}`, http.StatusBadRequest)
+		return
+	}
+
+	recorded, err := h.recordPaymentEvent(ctx, transactionID, status)
+	if err != nil {
+		log.Error("Failed to record payment event", "transaction_id", transactionID, "error", err)
+		http.Error(w, `{"error": "internal error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if !recorded {
+		log.Info("Duplicate Paytrail callback delivery, retrying enqueue", "transaction_id", transactionID)
+	}
+
+	err = h.queue.EnqueuePaymentCallback(queue.PaymentCallbackPayload{
+		TransactionID: transactionID,
+		OrderID:       orderID,
+		Status:        status,
+	})
+	if err != nil {
+		log.Error("Failed to enqueue payment callback", "transaction_id", transactionID, "error", err)
+		http.Error(w, `{"error": "internal error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// recordPaymentEvent inserts a payment_events row for transactionID,
+// reporting false (and no error) if one already exists. payment_id is
+// left NULL: resolving it to a payments row is the queue handler's job,
+// once it holds that row locked for the state transition.
+func (h *PaymentHandler) recordPaymentEvent(ctx context.Context, transactionID, status string) (bool, error) {
+	res, err := h.db.ExecContext(ctx, `
+		INSERT INTO payment_events (transaction_id, status)
+		VALUES ($1, $2)
+		ON CONFLICT (transaction_id) DO NOTHING
+	`, transactionID, status)
+	if err != nil {
+		return false, fmt.Errorf("insert payment event: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return n > 0, nil
+}
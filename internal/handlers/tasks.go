@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+
+	"sb-module/internal/events"
+	"sb-module/internal/queue"
+	"sb-module/pkg/logger"
+)
+
+// paymentStatus/orderStatus values this package writes. Paytrail reports
+// "ok" or "fail" on checkout-status; anything else is a protocol error,
+// not a business outcome, and is rejected rather than guessed at.
+const (
+	paytrailStatusOK   = "ok"
+	paytrailStatusFail = "fail"
+
+	paymentStatusPending   = "pending"
+	paymentStatusSucceeded = "succeeded"
+	paymentStatusFailed    = "failed"
+
+	orderStatusPaid          = "paid"
+	orderStatusPaymentFailed = "payment_failed"
+)
+
+// NewPaymentCallbackTaskHandler builds the TaskPaymentCallback handler:
+// the work paymentHandler.HandleCallback used to do inline before the
+// queue existed. It locks the order's payment row, verifies the
+// transition out of "pending" is one it hasn't already applied (so a
+// retried task is a no-op), and updates payments and orders in the same
+// transaction as the outbox row for payment.succeeded/payment.failed.
+// The order confirmation and receipt generation follow-up tasks are
+// enqueued whenever the payment is (or already was) succeeded — not only
+// on the delivery that performed the transition — because
+// EnqueuePaymentCallback's own retries must be able to pick up an enqueue
+// that failed after applyPaymentCallback's transaction had already
+// committed; EnqueueOrderConfirmation/EnqueueReceiptGenerate dedupe on
+// OrderID so this never double-sends. It never talks to an
+// events.Publisher directly: the outbox row it writes reaches one out of
+// band, via events.Relay.
+func NewPaymentCallbackTaskHandler(db *sql.DB, log *logger.Logger, queueClient *queue.Client) queue.Handler {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload queue.PaymentCallbackPayload
+		if err := queue.DecodePayload(task, &payload); err != nil {
+			return err
+		}
+
+		applied, orderID, status, err := applyPaymentCallback(ctx, db, payload)
+		if err != nil {
+			return fmt.Errorf("apply payment callback: %w", err)
+		}
+		if !applied {
+			log.Info("Payment callback already applied, retrying follow-up enqueue", "transaction_id", payload.TransactionID)
+		}
+
+		if status != paymentStatusSucceeded {
+			return nil
+		}
+
+		if err := queueClient.EnqueueOrderConfirmation(queue.OrderConfirmationPayload{OrderID: orderID}); err != nil {
+			return fmt.Errorf("enqueue order confirmation: %w", err)
+		}
+		if err := queueClient.EnqueueReceiptGenerate(queue.ReceiptGeneratePayload{OrderID: orderID}); err != nil {
+			return fmt.Errorf("enqueue receipt generate: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// applyPaymentCallback does the state transition in one transaction:
+// lock the payment row, skip if it's no longer "pending" (already
+// handled by an earlier delivery of this task), update payments/orders,
+// and append the outbox event. It reports whether it actually applied a
+// transition, and the payment's status as of return (the freshly-applied
+// one, or the one an earlier delivery already committed) so the caller
+// can decide whether follow-up work is still owed regardless of which
+// delivery performed the transition.
+func applyPaymentCallback(ctx context.Context, db *sql.DB, payload queue.PaymentCallbackPayload) (applied bool, orderID string, status string, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, "", "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		paymentID     int64
+		orderIDNum    int64
+		amountCents   int64
+		currentStatus string
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, order_id, amount_cents, status
+		FROM payments
+		WHERE order_id::text = $1
+		FOR UPDATE
+	`, payload.OrderID).Scan(&paymentID, &orderIDNum, &amountCents, &currentStatus)
+	if err != nil {
+		return false, "", "", fmt.Errorf("lock payment for order %s: %w", payload.OrderID, err)
+	}
+
+	if currentStatus != paymentStatusPending {
+		return false, payload.OrderID, currentStatus, nil
+	}
+
+	var (
+		newPaymentStatus string
+		newOrderStatus   string
+		topic            string
+	)
+	switch payload.Status {
+	case paytrailStatusOK:
+		newPaymentStatus, newOrderStatus, topic = paymentStatusSucceeded, orderStatusPaid, events.TopicPaymentSucceeded
+	case paytrailStatusFail:
+		newPaymentStatus, newOrderStatus, topic = paymentStatusFailed, orderStatusPaymentFailed, events.TopicPaymentFailed
+	default:
+		return false, "", "", fmt.Errorf("unrecognized checkout-status %q", payload.Status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE payments SET status = $1, updated_at = now() WHERE id = $2`, newPaymentStatus, paymentID); err != nil {
+		return false, "", "", fmt.Errorf("update payment %d: %w", paymentID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1, updated_at = now() WHERE id = $2`, newOrderStatus, orderIDNum); err != nil {
+		return false, "", "", fmt.Errorf("update order %d: %w", orderIDNum, err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE payment_events SET payment_id = $1 WHERE transaction_id = $2`, paymentID, payload.TransactionID); err != nil {
+		return false, "", "", fmt.Errorf("link payment event %s: %w", payload.TransactionID, err)
+	}
+
+	var eventPayload any
+	if newPaymentStatus == paymentStatusSucceeded {
+		eventPayload = events.PaymentSucceededPayload{
+			PaymentID:     fmt.Sprintf("%d", paymentID),
+			OrderID:       payload.OrderID,
+			TransactionID: payload.TransactionID,
+			AmountCents:   amountCents,
+		}
+	} else {
+		eventPayload = events.PaymentFailedPayload{
+			PaymentID:     fmt.Sprintf("%d", paymentID),
+			OrderID:       payload.OrderID,
+			TransactionID: payload.TransactionID,
+			Reason:        "paytrail reported checkout-status=fail",
+		}
+	}
+	if _, err := events.AppendOutbox(ctx, tx, topic, eventPayload, ""); err != nil {
+		return false, "", "", fmt.Errorf("append outbox event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, "", "", fmt.Errorf("commit: %w", err)
+	}
+
+	return true, payload.OrderID, newPaymentStatus, nil
+}
+
+// NewOrderConfirmationTaskHandler builds the TaskOrderConfirmation
+// handler: it looks up the order's owner and enqueues the confirmation
+// email, now that the payment succeeded.
+func NewOrderConfirmationTaskHandler(db *sql.DB, log *logger.Logger, queueClient *queue.Client) queue.Handler {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload queue.OrderConfirmationPayload
+		if err := queue.DecodePayload(task, &payload); err != nil {
+			return err
+		}
+
+		var userID int64
+		err := db.QueryRowContext(ctx, `SELECT user_id FROM orders WHERE id::text = $1`, payload.OrderID).Scan(&userID)
+		if err != nil {
+			return fmt.Errorf("look up order %s owner: %w", payload.OrderID, err)
+		}
+
+		err = queueClient.EnqueueEmailSend(queue.EmailSendPayload{
+			To:       fmt.Sprintf("user:%d", userID),
+			Template: "order_confirmation",
+			Data:     map[string]any{"order_id": payload.OrderID},
+		})
+		if err != nil {
+			return fmt.Errorf("enqueue order confirmation email: %w", err)
+		}
+
+		log.Info("Order confirmation queued", "order_id", payload.OrderID)
+		return nil
+	}
+}
+
+// NewEmailSendTaskHandler builds the TaskEmailSend handler. Sending mail
+// is out of scope here (no SMTP/provider client exists in this tree
+// yet); the handler just logs what would have been sent, so the task
+// still completes (and doesn't endlessly retry) once the rest of the
+// pipeline is wired up.
+func NewEmailSendTaskHandler(log *logger.Logger) queue.Handler {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload queue.EmailSendPayload
+		if err := queue.DecodePayload(task, &payload); err != nil {
+			return err
+		}
+
+		log.Info("Email send requested", "to", payload.To, "template", payload.Template)
+		return nil
+	}
+}
+
+// NewReceiptGenerateTaskHandler builds the TaskReceiptGenerate handler.
+// PDF generation is out of scope here (no PDF library in go.mod); it
+// logs the request so the order's receipt task still completes.
+func NewReceiptGenerateTaskHandler(db *sql.DB, log *logger.Logger) queue.Handler {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload queue.ReceiptGeneratePayload
+		if err := queue.DecodePayload(task, &payload); err != nil {
+			return err
+		}
+
+		log.Info("Receipt generation requested", "order_id", payload.OrderID)
+		return nil
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"sb-module/internal/config"
+)
+
+// kongInternalAuthHeader carries the shared secret Kong's upstream
+// service config is expected to attach to every request it proxies.
+const kongInternalAuthHeader = "X-Kong-Internal-Auth"
+
+// KongAuth restricts requests to callers that present internalAuth in
+// kongInternalAuthHeader and whose remote address is in the allow-list.
+// The allow-list is re-read from config.Current() on every request rather
+// than captured once at startup, so a hot-reloaded Kong.AllowedIPs takes
+// effect without restarting; startupAllowedIPs is the fallback used only
+// before the first config.Watch call has stored a Current().
+func KongAuth(internalAuth string, startupAllowedIPs []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get(kongInternalAuthHeader)), []byte(internalAuth)) != 1 {
+				http.Error(w, `{"error": "forbidden"}`, http.StatusForbidden)
+				return
+			}
+
+			if !ipAllowed(r.RemoteAddr, allowedIPs(startupAllowedIPs)) {
+				http.Error(w, `{"error": "forbidden"}`, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowedIPs prefers the live Kong.AllowedIPs from config.Current(),
+// falling back to fallback if Watch hasn't stored a Config yet.
+func allowedIPs(fallback []string) []string {
+	if cfg := config.Current(); cfg != nil {
+		return cfg.Kong.AllowedIPs
+	}
+	return fallback
+}
+
+// ipAllowed reports whether remoteAddr (host:port, as seen on
+// *http.Request.RemoteAddr) matches one of allowed. An empty allow-list
+// permits every address.
+func ipAllowed(remoteAddr string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	for _, ip := range allowed {
+		if strings.TrimSpace(ip) == host {
+			return true
+		}
+	}
+	return false
+}
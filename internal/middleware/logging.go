@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sb-module/pkg/logger"
+)
+
+// contextKey is an unexported type so values stored by this package never
+// collide with keys set by other packages.
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// RequestIDHeader is the header clients may set to propagate an existing
+// request ID (e.g. from an upstream gateway); if absent one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// Logging returns middleware that assigns each request a correlation ID,
+// attaches it to the response headers, and logs the request using a
+// child logger scoped to that ID so every downstream handler log line
+// carries the same request_id field.
+func Logging(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = uuid.NewString()
+			}
+
+			scoped := log.With("request_id", requestID)
+			ctx := context.WithValue(r.Context(), requestIDKey, scoped)
+
+			w.Header().Set(RequestIDHeader, requestID)
+
+			start := time.Now()
+			scoped.Info("Request started", "method", r.Method, "path", r.URL.Path)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			scoped.Info("Request completed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		})
+	}
+}
+
+// LoggerFromContext returns the request-scoped logger attached by Logging,
+// falling back to fallback if the request has none (e.g. in tests).
+func LoggerFromContext(ctx context.Context, fallback *logger.Logger) *logger.Logger {
+	if l, ok := ctx.Value(requestIDKey).(*logger.Logger); ok {
+		return l
+	}
+	return fallback
+}
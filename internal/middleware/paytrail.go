@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"sb-module/internal/paytrail"
+	"sb-module/pkg/logger"
+)
+
+// PaytrailSignature rejects any request whose "signature" query
+// parameter does not match the HMAC Paytrail computes over its
+// checkout-* query parameters and the request body, keyed by secret. It
+// applies to the callback webhook and to the success/cancel redirects,
+// all of which Paytrail signs this way — as query parameters, the same
+// ones internal/handlers.PaymentHandler reads checkout-transaction-id,
+// checkout-reference, and checkout-status from, not as headers.
+func PaytrailSignature(secret string, log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				var err error
+				body, err = io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, `{"error": "could not read request body"}`, http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			if err := paytrail.VerifySignature(r.URL.Query(), body, secret); err != nil {
+				LoggerFromContext(r.Context(), log).Warn("Rejected Paytrail request with invalid signature",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+					"error", err,
+				)
+				http.Error(w, `{"error": "invalid signature"}`, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
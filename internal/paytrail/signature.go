@@ -0,0 +1,104 @@
+// Package paytrail implements the parts of the Paytrail payment API that
+// are shared between the HTTP handler and the signature-verification
+// middleware, starting with HMAC signature verification for callbacks.
+package paytrail
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// signatureParam is the query parameter Paytrail sends the computed HMAC
+// in. Paytrail signs the callback webhook and the success/cancel
+// redirects identically: as checkout-* query parameters, not headers —
+// which is also how internal/handlers reads checkout-transaction-id,
+// checkout-reference, and checkout-status off these same requests.
+const signatureParam = "signature"
+
+// algorithmParam tells us which hash function the signature above used.
+const algorithmParam = "checkout-algorithm"
+
+// paramPrefix marks the query parameters that participate in the
+// signature: every parameter whose name starts with this
+// (case-insensitive) is included, in sorted order.
+const paramPrefix = "checkout-"
+
+// VerifySignature recomputes the HMAC Paytrail attaches to a request and
+// compares it, in constant time, against the "signature" query
+// parameter. It returns an error describing the mismatch; callers should
+// treat any non-nil error as "reject the request".
+//
+// Algorithm (per Paytrail's HMAC calculation spec): collect every query
+// parameter whose name starts with "checkout-", lowercase the names,
+// sort them alphabetically, join as "name:value\n" lines, append the raw
+// request body (empty string for GET requests with no body), and HMAC
+// the result with the merchant secret. The hash function is SHA-256
+// when checkout-algorithm is absent or says sha256, SHA-512 when it says
+// sha512 (parameter name and value matched case-insensitively); any other
+// value is rejected with an error.
+func VerifySignature(values url.Values, body []byte, secret string) error {
+	signature := values.Get(signatureParam)
+	if signature == "" {
+		return fmt.Errorf("paytrail: missing %s parameter", signatureParam)
+	}
+
+	computed, err := computeSignature(values, body, secret)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(strings.ToLower(signature))) != 1 {
+		return fmt.Errorf("paytrail: signature mismatch")
+	}
+
+	return nil
+}
+
+func computeSignature(values url.Values, body []byte, secret string) (string, error) {
+	var names []string
+	for name := range values {
+		if strings.HasPrefix(strings.ToLower(name), paramPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	algorithm := ""
+	var b strings.Builder
+	for _, name := range names {
+		if strings.EqualFold(name, algorithmParam) {
+			algorithm = values.Get(name)
+		}
+		fmt.Fprintf(&b, "%s:%s\n", strings.ToLower(name), values.Get(name))
+	}
+	b.Write(body)
+
+	mac, err := newMAC(algorithm, secret)
+	if err != nil {
+		return "", err
+	}
+	mac.Write([]byte(b.String()))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func newMAC(algorithm, secret string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return hmac.New(sha256.New, []byte(secret)), nil
+	case "sha512":
+		return hmac.New(sha512.New, []byte(secret)), nil
+	default:
+		return nil, fmt.Errorf("paytrail: unsupported %s %q", algorithmParam, algorithm)
+	}
+}
@@ -0,0 +1,87 @@
+package paytrail
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// sign computes the signature VerifySignature would accept for
+// values/body under secret, so tests can build requests that are valid
+// by construction.
+func sign(t *testing.T, values url.Values, body []byte, secret string) string {
+	t.Helper()
+	signature, err := computeSignature(values, body, secret)
+	if err != nil {
+		t.Fatalf("computeSignature: %v", err)
+	}
+	return signature
+}
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "sekret"
+	body := []byte(`{"amount": 1000}`)
+
+	baseValues := func() url.Values {
+		v := url.Values{}
+		v.Set("checkout-transaction-id", "txn-123")
+		v.Set("checkout-reference", "order-456")
+		v.Set("checkout-status", "ok")
+		return v
+	}
+
+	t.Run("sha256 happy path", func(t *testing.T) {
+		values := baseValues()
+		values.Set(algorithmParam, "sha256")
+		values.Set(signatureParam, sign(t, values, body, secret))
+
+		if err := VerifySignature(values, body, secret); err != nil {
+			t.Fatalf("VerifySignature: %v", err)
+		}
+	})
+
+	t.Run("sha512 happy path", func(t *testing.T) {
+		values := baseValues()
+		values.Set(algorithmParam, "sha512")
+		values.Set(signatureParam, sign(t, values, body, secret))
+
+		if err := VerifySignature(values, body, secret); err != nil {
+			t.Fatalf("VerifySignature: %v", err)
+		}
+	})
+
+	t.Run("missing signature parameter", func(t *testing.T) {
+		values := baseValues()
+
+		if err := VerifySignature(values, body, secret); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		values := baseValues()
+		values.Set(signatureParam, sign(t, values, body, secret))
+
+		if err := VerifySignature(values, []byte(`{"amount": 999999}`), secret); err == nil {
+			t.Fatal("expected a signature mismatch, got nil")
+		}
+	})
+
+	t.Run("case-insensitive parameter name matching", func(t *testing.T) {
+		// Unlike http.Header, url.Values doesn't canonicalize keys, so a
+		// request could in principle carry a differently-cased
+		// checkout-* parameter name. computeSignature must still count
+		// it toward the signed set, and lowercase the algorithm name and
+		// the hex signature itself, since Paytrail doesn't guarantee a
+		// case on either.
+		values := baseValues()
+		values.Set("CHECKOUT-ALGORITHM", "SHA512")
+
+		signature := sign(t, values, body, secret)
+		values.Set(signatureParam, strings.ToUpper(signature))
+
+		if err := VerifySignature(values, body, secret); err != nil {
+			t.Fatalf("VerifySignature: %v", err)
+		}
+	})
+}
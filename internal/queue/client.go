@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"sb-module/internal/config"
+)
+
+// Client enqueues typed tasks onto the Redis-backed queue. It wraps
+// *asynq.Client so callers never construct asynq.Task payloads by hand.
+type Client struct {
+	inner    *asynq.Client
+	maxRetry int
+}
+
+// NewClient builds a Client from the Redis and Queue sections of cfg.
+func NewClient(cfg *config.Config) *Client {
+	return &Client{inner: asynq.NewClient(redisOpt(cfg)), maxRetry: cfg.Queue.MaxRetry}
+}
+
+// Close releases the underlying Redis connection pool.
+func (c *Client) Close() error {
+	return c.inner.Close()
+}
+
+// EnqueuePaymentCallback enqueues follow-up work for a verified Paytrail
+// callback. The task is deduplicated on TransactionID for 24h, and the
+// error that dedup produces is swallowed as success, so HandleCallback
+// can call this unconditionally on every delivery — including a
+// redelivered webhook whose payment_events insert was itself a
+// duplicate — without ever risking a second run while the first is
+// still in flight or, if the unique lock already expired, anything
+// worse than a harmless re-run of the now-idempotent callback task.
+func (c *Client) EnqueuePaymentCallback(payload PaymentCallbackPayload) error {
+	task, err := NewPaymentCallbackTask(payload)
+	if err != nil {
+		return err
+	}
+	_, err = c.inner.Enqueue(task,
+		asynq.Queue("payments"),
+		asynq.MaxRetry(c.maxRetry),
+		asynq.Unique(24*time.Hour),
+		asynq.TaskID("payment-callback:"+payload.TransactionID),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("enqueue payment callback: %w", err)
+	}
+	return nil
+}
+
+// EnqueueOrderConfirmation enqueues the order confirmation email flow
+// (receipt generation is enqueued separately). The task is deduplicated on OrderID for 24h so re-enqueuing it —
+// e.g. a payment-callback task retried after its own transaction already
+// committed — is a no-op rather than a second email.
+func (c *Client) EnqueueOrderConfirmation(payload OrderConfirmationPayload) error {
+	task, err := NewOrderConfirmationTask(payload)
+	if err != nil {
+		return err
+	}
+	_, err = c.inner.Enqueue(task,
+		asynq.Queue("orders"),
+		asynq.MaxRetry(c.maxRetry),
+		asynq.Unique(24*time.Hour),
+		asynq.TaskID("order-confirmation:"+payload.OrderID),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("enqueue order confirmation: %w", err)
+	}
+	return nil
+}
+
+// EnqueueEmailSend enqueues a single transactional email.
+func (c *Client) EnqueueEmailSend(payload EmailSendPayload) error {
+	task, err := NewEmailSendTask(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := c.inner.Enqueue(task, asynq.Queue("default"), asynq.MaxRetry(c.maxRetry)); err != nil {
+		return fmt.Errorf("enqueue email send: %w", err)
+	}
+	return nil
+}
+
+// EnqueueReceiptGenerate enqueues PDF receipt generation for an order.
+// Deduplicated on OrderID for the same reason EnqueueOrderConfirmation is:
+// the caller re-attempts this independently of whether it has already
+// succeeded once.
+func (c *Client) EnqueueReceiptGenerate(payload ReceiptGeneratePayload) error {
+	task, err := NewReceiptGenerateTask(payload)
+	if err != nil {
+		return err
+	}
+	_, err = c.inner.Enqueue(task,
+		asynq.Queue("default"),
+		asynq.MaxRetry(c.maxRetry),
+		asynq.Unique(24*time.Hour),
+		asynq.TaskID("receipt-generate:"+payload.OrderID),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("enqueue receipt generate: %w", err)
+	}
+	return nil
+}
+
+func redisOpt(cfg *config.Config) asynq.RedisClientOpt {
+	return asynq.RedisClientOpt{
+		Addr:     cfg.Redis.Addr(),
+		Password: cfg.Redis.Password(),
+		DB:       cfg.Redis.DB,
+		PoolSize: cfg.Redis.PoolSize,
+	}
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"sb-module/internal/config"
+	"sb-module/pkg/logger"
+)
+
+// Handler processes one task type. Handlers must be idempotent: asynq
+// retries on error using exponential backoff, and a redelivered webhook or
+// an at-least-once enqueue can hand the same logical task to a handler
+// more than once.
+type Handler func(ctx context.Context, task *asynq.Task) error
+
+// Server consumes tasks enqueued by a Client and dispatches them to
+// registered Handlers. Unprocessable tasks are retried with exponential
+// backoff and, once retries are exhausted, moved to asynq's built-in
+// dead-letter queue (the "archived" state) for manual inspection.
+type Server struct {
+	inner *asynq.Server
+	mux   *asynq.ServeMux
+	log   *logger.Logger
+}
+
+// NewServer builds a Server from the Redis and Queue sections of cfg.
+func NewServer(cfg *config.Config, log *logger.Logger) *Server {
+	concurrency := cfg.Queue.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	inner := asynq.NewServer(redisOpt(cfg), asynq.Config{
+		Concurrency: concurrency,
+		Queues: map[string]int{
+			"payments": 6,
+			"orders":   3,
+			"default":  1,
+		},
+		RetryDelayFunc: retryDelayFunc(cfg),
+		ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+			log.Error("Task failed", "type", task.Type(), "error", err)
+		}),
+	})
+
+	return &Server{inner: inner, mux: asynq.NewServeMux(), log: log}
+}
+
+// retryDelayFunc builds the exponential backoff asynq uses between retry
+// attempts, doubling from cfg.Queue.MinBackoff up to cfg.Queue.MaxBackoff.
+// It falls back to asynq.DefaultRetryDelayFunc when either bound isn't
+// configured, so a zero-value config.Config (e.g. in tests) still gets a
+// sane delay instead of retrying with no backoff at all.
+func retryDelayFunc(cfg *config.Config) asynq.RetryDelayFunc {
+	min, max := cfg.Queue.MinBackoff, cfg.Queue.MaxBackoff
+	if min <= 0 || max <= 0 {
+		return asynq.DefaultRetryDelayFunc
+	}
+	return func(n int, e error, t *asynq.Task) time.Duration {
+		delay := min * time.Duration(1<<uint(n))
+		if delay <= 0 || delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+// Handle registers handler for taskType.
+func (s *Server) Handle(taskType string, handler Handler) {
+	s.mux.HandleFunc(taskType, handler)
+}
+
+// Run starts consuming tasks. It blocks until the server is shut down or
+// a fatal error occurs.
+func (s *Server) Run() error {
+	if err := s.inner.Run(s.mux); err != nil {
+		return fmt.Errorf("queue server: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops the server, waiting for in-flight tasks to finish.
+func (s *Server) Shutdown() {
+	s.inner.Shutdown()
+}
+
+// DecodePayload is a small helper the internal/handlers Handlers use to
+// unmarshal a task's payload into a typed struct with a consistent error
+// message. It's exported because Handle's registrants live in a separate
+// package (internal/handlers) from Server itself.
+func DecodePayload(task *asynq.Task, v any) error {
+	if err := json.Unmarshal(task.Payload(), v); err != nil {
+		return fmt.Errorf("decode %s payload: %w", task.Type(), err)
+	}
+	return nil
+}
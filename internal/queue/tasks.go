@@ -0,0 +1,87 @@
+// Package queue provides an asynq-backed job queue for work that must not
+// block an HTTP request/response cycle: payment callback follow-up, order
+// fulfillment, and transactional email.
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+// Task type names. asynq routes tasks to handlers by this string, so these
+// double as the wire contract between enqueuers and the Server.
+const (
+	TaskPaymentCallback   = "payment:callback"
+	TaskOrderConfirmation = "order:confirmation"
+	TaskEmailSend         = "email:send"
+	TaskReceiptGenerate   = "receipt:generate"
+)
+
+// PaymentCallbackPayload is the body of a TaskPaymentCallback task. The
+// handler does the work that paymentHandler.HandleCallback used to do
+// inline: verifying state transitions, updating the order, and fanning
+// out the confirmation/receipt tasks below.
+type PaymentCallbackPayload struct {
+	TransactionID string `json:"transaction_id"`
+	OrderID       string `json:"order_id"`
+	Status        string `json:"status"`
+}
+
+// OrderConfirmationPayload is the body of a TaskOrderConfirmation task.
+type OrderConfirmationPayload struct {
+	OrderID string `json:"order_id"`
+}
+
+// EmailSendPayload is the body of a TaskEmailSend task.
+type EmailSendPayload struct {
+	To       string         `json:"to"`
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// ReceiptGeneratePayload is the body of a TaskReceiptGenerate task.
+type ReceiptGeneratePayload struct {
+	OrderID string `json:"order_id"`
+}
+
+// NewPaymentCallbackTask builds a TaskPaymentCallback task.
+// Client.EnqueuePaymentCallback enqueues it with a task ID and a 24h
+// uniqueness lock keyed on TransactionID, so a redelivered webhook adds no
+// second task while the first is held; a later re-run is a no-op in the
+// callback handler once the payment is no longer pending.
+func NewPaymentCallbackTask(payload PaymentCallbackPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payment callback payload: %w", err)
+	}
+	return asynq.NewTask(TaskPaymentCallback, data), nil
+}
+
+// NewOrderConfirmationTask builds a TaskOrderConfirmation task.
+func NewOrderConfirmationTask(payload OrderConfirmationPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal order confirmation payload: %w", err)
+	}
+	return asynq.NewTask(TaskOrderConfirmation, data), nil
+}
+
+// NewEmailSendTask builds a TaskEmailSend task.
+func NewEmailSendTask(payload EmailSendPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal email send payload: %w", err)
+	}
+	return asynq.NewTask(TaskEmailSend, data), nil
+}
+
+// NewReceiptGenerateTask builds a TaskReceiptGenerate task.
+func NewReceiptGenerateTask(payload ReceiptGeneratePayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal receipt generate payload: %w", err)
+	}
+	return asynq.NewTask(TaskReceiptGenerate, data), nil
+}
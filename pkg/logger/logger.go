@@ -1,34 +1,118 @@
+// Package logger provides a structured, leveled logger built on zap.
 package logger
 
-import "log"
+import (
+	"os"
+	"strings"
 
-type Logger struct{}
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
-func New() *Logger {
-    return &Logger{}
+// Logger wraps a zap.SugaredLogger so call sites can pass loosely-typed
+// key/value pairs (e.g. log.Info("msg", "key", value)) without depending
+// on zap directly.
+type Logger struct {
+	sugar *zap.SugaredLogger
+	level zap.AtomicLevel
 }
 
-func (l *Logger) Info(msg string) {
-    log.Println("INFO:", msg)
+// New builds a Logger for the given level ("debug", "info", "warn", "error").
+// Unrecognized levels fall back to "info". Production-style levels
+// (anything other than "debug") use a JSON encoder suitable for log
+// aggregation; "debug" uses a human-readable console encoder. The level
+// is held in an atomic so SetLevel can change it afterwards without
+// rebuilding the logger.
+func New(level string) *Logger {
+	atomicLevel := zap.NewAtomicLevelAt(parseLevel(level))
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = "timestamp"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	var encoder zapcore.Encoder
+	if atomicLevel.Level() == zapcore.DebugLevel {
+		encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	} else {
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	}
+
+	core := zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), atomicLevel)
+	zl := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+
+	return &Logger{sugar: zl.Sugar(), level: atomicLevel}
+}
+
+// NewFromEnvironment derives the log level from the application's
+// environment and debug flag, using the same rules as the config
+// package's deriveLogLevel: "warn" in production, otherwise "debug" when
+// debugMode is set and "info" when it isn't.
+func NewFromEnvironment(environment string, debugMode bool) *Logger {
+	if environment == "production" {
+		return New("warn")
+	}
+	if debugMode {
+		return New("debug")
+	}
+	return New("info")
+}
+
+func parseLevel(level string) zapcore.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return zapcore.DebugLevel
+	case "warn", "warning":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// With returns a child Logger that attaches the given key/value pairs to
+// every subsequent log line. Use it to scope a logger to a single request.
+// The child shares its parent's level, so SetLevel on either affects both.
+func (l *Logger) With(keysAndValues ...any) *Logger {
+	return &Logger{sugar: l.sugar.With(keysAndValues...), level: l.level}
+}
+
+// SetLevel changes the minimum level this Logger (and every Logger
+// derived from it via With) emits at, effective immediately. config.Watch
+// calls this from its reload hook so a hot-reloaded log level takes
+// effect without restarting the process.
+func (l *Logger) SetLevel(level string) {
+	l.level.SetLevel(parseLevel(level))
+}
+
+// Debug logs msg at debug level with structured key/value fields.
+func (l *Logger) Debug(msg string, keysAndValues ...any) {
+	l.sugar.Debugw(msg, keysAndValues...)
 }
 
-func (l *Logger) Error(msg string) {
-    log.Println("ERROR:", msg)
+// Info logs msg at info level with structured key/value fields.
+func (l *Logger) Info(msg string, keysAndValues ...any) {
+	l.sugar.Infow(msg, keysAndValues...)
 }
 
-func (l *Logger) Debug(msg string) {
-    log.Println("DEBUG:", msg)
+// Warn logs msg at warn level with structured key/value fields.
+func (l *Logger) Warn(msg string, keysAndValues ...any) {
+	l.sugar.Warnw(msg, keysAndValues...)
 }
 
-// Global functions for convenience
-func Info(msg string) {
-    log.Println("INFO:", msg)
+// Error logs msg at error level with structured key/value fields.
+func (l *Logger) Error(msg string, keysAndValues ...any) {
+	l.sugar.Errorw(msg, keysAndValues...)
 }
 
-func Error(msg string) {
-    log.Println("ERROR:", msg)
+// Fatal logs msg at fatal level with structured key/value fields and then
+// exits the process with status 1.
+func (l *Logger) Fatal(msg string, keysAndValues ...any) {
+	l.sugar.Fatalw(msg, keysAndValues...)
 }
 
-func Debug(msg string) {
-    log.Println("DEBUG:", msg)
+// Sync flushes any buffered log entries. Call it before process exit.
+func (l *Logger) Sync() error {
+	return l.sugar.Sync()
 }